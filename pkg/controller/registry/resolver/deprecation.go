@@ -0,0 +1,197 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+// DeprecationPolicy controls how a removed GVK is handled during step generation.
+type DeprecationPolicy string
+
+const (
+	// DeprecationPolicyWarn surfaces a StepWarning for deprecated or removed GVKs but still emits the step.
+	DeprecationPolicyWarn DeprecationPolicy = "warn"
+	// DeprecationPolicyFail causes resolution to fail with a DeprecatedAPIError when a step targets a
+	// GVK that is already removed from the target cluster's server version.
+	DeprecationPolicyFail DeprecationPolicy = "fail"
+)
+
+// DeprecationChecker reports whether a GVK is deprecated or removed on the target cluster.
+type DeprecationChecker interface {
+	// CheckDeprecated returns whether gvk is deprecated and/or already removed on the target cluster,
+	// along with a human-readable message suitable for surfacing on a StepWarning. removed implies
+	// deprecated.
+	CheckDeprecated(gvk schema.GroupVersionKind) (deprecated, removed bool, message string)
+}
+
+// removedGVK describes a GVK that was removed as of a given Kubernetes minor version, along with the
+// minor version it was first deprecated in (0 if it was never formally deprecated before removal).
+type removedGVK struct {
+	gvk             schema.GroupVersionKind
+	deprecatedMinor int
+	removedMinor    int
+}
+
+// builtinRemovedGVKs is a small, hand-maintained table of well known API removals. It is intentionally
+// not exhaustive: discoveryDeprecationChecker falls back to discovery.ServerPreferredResources to catch
+// anything not listed here.
+var builtinRemovedGVKs = []removedGVK{
+	{gvk: schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}, deprecatedMinor: 16, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "MutatingWebhookConfiguration"}, deprecatedMinor: 16, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration"}, deprecatedMinor: 16, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRole"}, deprecatedMinor: 17, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRoleBinding"}, deprecatedMinor: 17, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "Role"}, deprecatedMinor: 17, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "RoleBinding"}, deprecatedMinor: 17, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}, deprecatedMinor: 14, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}, deprecatedMinor: 19, removedMinor: 22},
+	{gvk: schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy"}, deprecatedMinor: 21, removedMinor: 25},
+	{gvk: schema.GroupVersionKind{Group: "batch", Version: "v1beta1", Kind: "CronJob"}, deprecatedMinor: 21, removedMinor: 25},
+	{gvk: schema.GroupVersionKind{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema"}, deprecatedMinor: 26, removedMinor: 29},
+	{gvk: schema.GroupVersionKind{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "PriorityLevelConfiguration"}, deprecatedMinor: 26, removedMinor: 29},
+}
+
+// DeprecatedAPIError is returned when a step targets a GVK already removed from the target cluster's
+// server version and the configured DeprecationPolicy is DeprecationPolicyFail.
+type DeprecatedAPIError struct {
+	GVK     schema.GroupVersionKind
+	Message string
+}
+
+func (e *DeprecatedAPIError) Error() string {
+	return fmt.Sprintf("step targets removed API %s: %s", e.GVK, e.Message)
+}
+
+// discoveryDeprecationChecker answers deprecation questions using the builtin removal table, keyed off
+// the target cluster's own server version.
+type discoveryDeprecationChecker struct {
+	serverMinor int
+	disc        discovery.DiscoveryInterface
+
+	// mu/served memoize the discovery.ServerPreferredResources fallback: CheckDeprecated is called once
+	// per generated step, and without memoization a bundle with N steps outside the builtin table would
+	// cost N live API-server round trips instead of one. Only a successful result is cached - a
+	// transient discovery error isn't, so one early hiccup can't permanently disable the fallback for
+	// the rest of the steps in a resolution.
+	mu     sync.Mutex
+	served map[schema.GroupVersionKind]bool
+}
+
+// NewDiscoveryDeprecationChecker returns a DeprecationChecker backed by disc's reported server version
+// plus the builtin removal table. disc may also be used to resolve GVKs that aren't in the table via
+// ServerPreferredResources.
+func NewDiscoveryDeprecationChecker(disc discovery.DiscoveryInterface) (DeprecationChecker, error) {
+	serverVersion, err := disc.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine target cluster server version: %w", err)
+	}
+	minor, err := parseMinorVersion(serverVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse target cluster server version %q: %w", serverVersion.String(), err)
+	}
+	return &discoveryDeprecationChecker{serverMinor: minor, disc: disc}, nil
+}
+
+func (c *discoveryDeprecationChecker) CheckDeprecated(gvk schema.GroupVersionKind) (deprecated, removed bool, message string) {
+	for _, entry := range builtinRemovedGVKs {
+		if entry.gvk != gvk {
+			continue
+		}
+		if entry.removedMinor != 0 && c.serverMinor >= entry.removedMinor {
+			return true, true, fmt.Sprintf("%s is removed as of Kubernetes 1.%d", gvkString(gvk), entry.removedMinor)
+		}
+		if entry.deprecatedMinor != 0 && c.serverMinor >= entry.deprecatedMinor {
+			removedAt := "a future release"
+			if entry.removedMinor != 0 {
+				removedAt = fmt.Sprintf("1.%d", entry.removedMinor)
+			}
+			return true, false, fmt.Sprintf("%s is deprecated as of Kubernetes 1.%d, removed in %s", gvkString(gvk), entry.deprecatedMinor, removedAt)
+		}
+		return false, false, ""
+	}
+
+	// Fall back to discovery for GVKs not covered by the builtin table: if the server doesn't serve it
+	// at all, treat it conservatively as removed rather than silently letting the step through.
+	if c.disc == nil {
+		return false, false, ""
+	}
+	served, err := c.servedGVKs()
+	if err != nil {
+		// Discovery is best-effort here; a failure shouldn't block resolution on its own.
+		return false, false, ""
+	}
+	if served[gvk] {
+		return false, false, ""
+	}
+	return true, true, fmt.Sprintf("%s is not served by the target cluster", gvkString(gvk))
+}
+
+// servedGVKs returns every GVK the target cluster serves, fetched via ServerPreferredResources and
+// memoized for the lifetime of the checker so repeated CheckDeprecated calls (one per generated step)
+// only hit the API server once. A failed fetch isn't memoized, so it's retried on the next call rather
+// than permanently disabling the fallback.
+func (c *discoveryDeprecationChecker) servedGVKs() (map[schema.GroupVersionKind]bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.served != nil {
+		return c.served, nil
+	}
+
+	resourceLists, err := c.disc.ServerPreferredResources()
+	if err != nil {
+		return nil, err
+	}
+	served := make(map[schema.GroupVersionKind]bool)
+	for _, list := range resourceLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, res := range list.APIResources {
+			served[gv.WithKind(res.Kind)] = true
+		}
+	}
+	c.served = served
+	return c.served, nil
+}
+
+// bundleOwnedGVKs returns the GVKs of the custom resources csv declares CRDs for. These must be exempt
+// from the "not served by the cluster" discovery fallback in CheckDeprecated: on a first-time install
+// the CRD that registers them is itself one of the bundle's own steps and may not have been applied (or
+// propagated) to the cluster yet, which would otherwise look identical to a removed API.
+func bundleOwnedGVKs(csv *v1alpha1.ClusterServiceVersion) map[schema.GroupVersionKind]bool {
+	owned := make(map[schema.GroupVersionKind]bool, len(csv.Spec.CustomResourceDefinitions.Owned))
+	for _, crd := range csv.Spec.CustomResourceDefinitions.Owned {
+		group := crd.Name
+		if idx := strings.Index(crd.Name, "."); idx >= 0 {
+			group = crd.Name[idx+1:]
+		}
+		owned[schema.GroupVersionKind{Group: group, Version: crd.Version, Kind: crd.Kind}] = true
+	}
+	return owned
+}
+
+func gvkString(gvk schema.GroupVersionKind) string {
+	if gvk.Group == "" {
+		return fmt.Sprintf("%s/%s %s", "core", gvk.Version, gvk.Kind)
+	}
+	return fmt.Sprintf("%s/%s %s", gvk.Group, gvk.Version, gvk.Kind)
+}
+
+// parseMinorVersion extracts the numeric minor version from a discovery version.Info, tolerating the
+// trailing "+" that some distributions (e.g. EKS, OpenShift) append.
+func parseMinorVersion(v *version.Info) (int, error) {
+	minor := v.Minor
+	var n int
+	if _, err := fmt.Sscanf(minor, "%d", &n); err != nil {
+		return 0, fmt.Errorf("unparsable minor version %q: %w", minor, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,156 @@
+package resolver
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+// fakeDiscovery stubs only the discovery.DiscoveryInterface method discoveryDeprecationChecker uses,
+// embedding the interface so the zero value still satisfies it.
+type fakeDiscovery struct {
+	discovery.DiscoveryInterface
+	calls     int
+	resources []*metav1.APIResourceList
+	err       error
+}
+
+func (f *fakeDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	f.calls++
+	return f.resources, f.err
+}
+
+func TestCheckDeprecatedBuiltinTable(t *testing.T) {
+	ingressGVK := schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}
+
+	tests := []struct {
+		name           string
+		serverMinor    int
+		gvk            schema.GroupVersionKind
+		wantDeprecated bool
+		wantRemoved    bool
+	}{
+		{name: "not yet deprecated", serverMinor: 18, gvk: ingressGVK, wantDeprecated: false, wantRemoved: false},
+		{name: "deprecated but not removed", serverMinor: 20, gvk: ingressGVK, wantDeprecated: true, wantRemoved: false},
+		{name: "removed", serverMinor: 22, gvk: ingressGVK, wantDeprecated: true, wantRemoved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &discoveryDeprecationChecker{serverMinor: tt.serverMinor}
+			deprecated, removed, message := c.CheckDeprecated(tt.gvk)
+			assert.Equal(t, tt.wantDeprecated, deprecated)
+			assert.Equal(t, tt.wantRemoved, removed)
+			if tt.wantDeprecated {
+				assert.NotEmpty(t, message)
+			}
+		})
+	}
+}
+
+func TestCheckDeprecatedDiscoveryFallbackIsMemoized(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+	disc := &fakeDiscovery{
+		resources: []*metav1.APIResourceList{
+			{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{{Kind: "Widget"}}},
+		},
+	}
+	c := &discoveryDeprecationChecker{serverMinor: 30, disc: disc}
+
+	deprecated, removed, _ := c.CheckDeprecated(gvk)
+	assert.False(t, deprecated)
+	assert.False(t, removed)
+
+	// Second call for an unrelated GVK not in the table should hit the same cached discovery result, not
+	// the server again.
+	deprecated, removed, _ = c.CheckDeprecated(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"})
+	assert.True(t, deprecated)
+	assert.True(t, removed)
+
+	require.Equal(t, 1, disc.calls, "ServerPreferredResources should only be called once regardless of how many GVKs are checked")
+}
+
+func TestCheckDeprecatedDiscoveryFallbackNotServedIsRemoved(t *testing.T) {
+	disc := &fakeDiscovery{resources: nil}
+	c := &discoveryDeprecationChecker{serverMinor: 30, disc: disc}
+
+	deprecated, removed, message := c.CheckDeprecated(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"})
+	assert.True(t, deprecated)
+	assert.True(t, removed)
+	assert.NotEmpty(t, message)
+}
+
+func TestCheckDeprecatedDiscoveryFallbackRetriesAfterTransientError(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+	disc := &fakeDiscovery{err: fmt.Errorf("connection refused")}
+	c := &discoveryDeprecationChecker{serverMinor: 30, disc: disc}
+
+	deprecated, removed, _ := c.CheckDeprecated(gvk)
+	assert.False(t, deprecated, "a transient discovery error must not be treated as removed")
+	assert.False(t, removed)
+	require.Equal(t, 1, disc.calls)
+
+	// The server recovers; a failed fetch must not have been cached, so this call should hit discovery
+	// again rather than silently reusing the first failure forever.
+	disc.err = nil
+	disc.resources = []*metav1.APIResourceList{
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{{Kind: "Gadget"}}},
+	}
+	deprecated, removed, _ = c.CheckDeprecated(gvk)
+	assert.True(t, deprecated, "Widget isn't served post-recovery and should now be reported removed")
+	assert.True(t, removed)
+	require.Equal(t, 2, disc.calls, "a prior discovery error must not be memoized")
+}
+
+func TestCheckDeprecatedNoDiscoveryFallsThrough(t *testing.T) {
+	c := &discoveryDeprecationChecker{serverMinor: 30}
+	deprecated, removed, _ := c.CheckDeprecated(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"})
+	assert.False(t, deprecated)
+	assert.False(t, removed)
+}
+
+func TestBundleOwnedGVKsExemptsOwnedCRKinds(t *testing.T) {
+	csv := &v1alpha1.ClusterServiceVersion{}
+	csv.Spec.CustomResourceDefinitions.Owned = []v1alpha1.CRDDescription{
+		{Name: "widgets.example.com", Version: "v1", Kind: "Widget"},
+	}
+
+	owned := bundleOwnedGVKs(csv)
+
+	assert.True(t, owned[schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}])
+	assert.False(t, owned[schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"}])
+}
+
+func TestCheckDeprecatedReportsNotYetServedBundleOwnedGVKAsRemoved(t *testing.T) {
+	// The discovery fallback alone would call this "removed" (not served), but
+	// NewStepResourceFromBundleWithOptions is expected to skip CheckDeprecated entirely for GVKs
+	// bundleOwnedGVKs reports as owned by the bundle's own CSV; exercise that exemption map directly
+	// here since CheckDeprecated itself has no notion of "owned".
+	csv := &v1alpha1.ClusterServiceVersion{}
+	csv.Spec.CustomResourceDefinitions.Owned = []v1alpha1.CRDDescription{
+		{Name: "widgets.example.com", Version: "v1", Kind: "Widget"},
+	}
+	exempt := bundleOwnedGVKs(csv)
+
+	disc := &fakeDiscovery{resources: nil}
+	c := &discoveryDeprecationChecker{serverMinor: 30, disc: disc}
+
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+	require.True(t, exempt[gvk], "precondition: Widget must be considered bundle-owned")
+
+	deprecated, removed, _ := c.CheckDeprecated(gvk)
+	assert.True(t, deprecated, "CheckDeprecated has no exemption of its own - callers must skip it for exempt GVKs")
+	assert.True(t, removed)
+}
+
+func TestGvkStringCoreGroup(t *testing.T) {
+	assert.Equal(t, "core/v1 Pod", gvkString(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}))
+}
@@ -0,0 +1,58 @@
+package resolver
+
+// StepGenerationOptions holds the cross-cutting behaviors that can be layered on top of plain step
+// generation (NewStepResourceFromBundle and friends) via StepOption. Each optional behavior is left
+// nil/zero by default so existing callers of the unmodified functions see no change in behavior.
+type StepGenerationOptions struct {
+	deprecationChecker DeprecationChecker
+	deprecationPolicy  DeprecationPolicy
+	clusterVersions    ClusterVersionGetter
+	signatureVerifier  SignatureVerifier
+	verificationPolicy VerificationPolicy
+	transformers       []StepTransformer
+	transformContext   TransformContext
+}
+
+// StepOption configures a StepGenerationOptions. Options are applied in the order they are passed to
+// NewStepResourceFromBundleWithOptions.
+type StepOption func(*StepGenerationOptions)
+
+// WithDeprecationChecker enables deprecated/removed GVK detection for generated steps. policy controls
+// whether a step targeting a GVK that is already removed from the target cluster causes resolution to
+// fail outright (DeprecationPolicyFail) or merely produces a StepWarning (DeprecationPolicyWarn).
+func WithDeprecationChecker(checker DeprecationChecker, policy DeprecationPolicy) StepOption {
+	return func(o *StepGenerationOptions) {
+		o.deprecationChecker = checker
+		o.deprecationPolicy = policy
+	}
+}
+
+// WithClusterVersionConstraints enables enforcement of a bundle's declared
+// com.redhat.openshift.versions / olm.kubeversions property against the target cluster's Kubernetes
+// version, as resolved by clusterVersions. A bundle whose declared range excludes the target cluster
+// fails resolution with a VersionConstraintError.
+func WithClusterVersionConstraints(clusterVersions ClusterVersionGetter) StepOption {
+	return func(o *StepGenerationOptions) {
+		o.clusterVersions = clusterVersions
+	}
+}
+
+// WithVerificationPolicy enables digest and (if verifier is non-nil) signature verification of bundle
+// content against its declared olm.artifact.digests / olm.artifact.signature properties, per policy.
+// verifier may be nil if only digest checking (no signature check) is desired.
+func WithVerificationPolicy(policy VerificationPolicy, verifier SignatureVerifier) StepOption {
+	return func(o *StepGenerationOptions) {
+		o.verificationPolicy = policy
+		o.signatureVerifier = verifier
+	}
+}
+
+// WithStepTransformers runs every generated step's object through the given ordered chain of
+// StepTransformers before it's serialized, with ctx supplying the target namespace(s) and catalog
+// information transformers need (see TransformContext).
+func WithStepTransformers(ctx TransformContext, transformers ...StepTransformer) StepOption {
+	return func(o *StepGenerationOptions) {
+		o.transformContext = ctx
+		o.transformers = transformers
+	}
+}
@@ -0,0 +1,271 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+// StepRef identifies a step by the same coordinates NewStepResourceFromObject assigns it.
+type StepRef struct {
+	Group, Version, Kind, Namespace, Name string
+}
+
+func stepRef(s v1alpha1.StepResource, namespace string) StepRef {
+	return StepRef{Group: s.Group, Version: s.Version, Kind: s.Kind, Namespace: namespace, Name: s.Name}
+}
+
+// CycleError is returned by StepGraph.TopoSort when the dependency graph contains a cycle.
+type CycleError struct {
+	Cycle []StepRef
+}
+
+func (e *CycleError) Error() string {
+	names := make([]string, len(e.Cycle))
+	for i, ref := range e.Cycle {
+		names[i] = fmt.Sprintf("%s/%s %s/%s", ref.Group, ref.Version, ref.Kind, ref.Name)
+	}
+	return fmt.Sprintf("cycle detected in step dependency graph: %s", strings.Join(names, " -> "))
+}
+
+// StepGraph records the DependsOn edges between a set of steps, keyed by StepRef rather than embedded
+// directly on v1alpha1.Step (which lives in a vendored API package we don't control).
+type StepGraph struct {
+	order     []StepRef
+	steps     map[StepRef]v1alpha1.StepResource
+	dependsOn map[StepRef][]StepRef
+}
+
+// BuildStepGraph computes the dependency edges for a set of steps generated from the same bundle:
+//   - every non-CSV step depends on the CSV step
+//   - a CustomResource depends on the step that installs its CustomResourceDefinition
+//   - a RoleBinding/ClusterRoleBinding depends on the ServiceAccount subjects it references
+//   - a namespaced object depends on its Namespace step, if one is present among steps
+//   - a Deployment step depends on the Secret/ConfigMap steps its own pod spec mounts or references
+//     via envFrom; the deployments declared in the CSV's StrategyDetailsDeployment are not inspected
+//
+// namespaces must be index-aligned with steps, as returned by NewStepResourceFromBundle.
+func BuildStepGraph(csv *v1alpha1.ClusterServiceVersion, steps []v1alpha1.StepResource, namespaces []string) (*StepGraph, error) {
+	g := &StepGraph{
+		steps:     make(map[StepRef]v1alpha1.StepResource, len(steps)),
+		dependsOn: make(map[StepRef][]StepRef, len(steps)),
+	}
+
+	var csvRef StepRef
+	byCRDName := make(map[string]StepRef)   // CRD full name (plural.group) -> CRD step ref
+	byNamespace := make(map[string]StepRef) // Namespace name -> Namespace step ref
+	bySAName := make(map[string]StepRef)    // ServiceAccount name -> SA step ref
+	byConfigRef := make(map[string]StepRef) // "Secret|ConfigMap"/name -> step ref
+
+	crdNameByKind := make(map[string]string, len(csv.Spec.CustomResourceDefinitions.Owned))
+	for _, crd := range csv.Spec.CustomResourceDefinitions.Owned {
+		crdNameByKind[crd.Kind] = crd.Name
+	}
+
+	decoded := make([]*unstructured.Unstructured, len(steps))
+	for i, s := range steps {
+		unst, err := decodeStepManifest(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode manifest for step %s/%s: %w", s.Kind, s.Name, err)
+		}
+		decoded[i] = unst
+
+		ref := stepRef(s, namespaces[i])
+		g.order = append(g.order, ref)
+		g.steps[ref] = s
+
+		switch s.Kind {
+		case v1alpha1.ClusterServiceVersionKind:
+			csvRef = ref
+		case "CustomResourceDefinition":
+			byCRDName[unst.GetName()] = ref
+		case "Namespace":
+			byNamespace[unst.GetName()] = ref
+		case "ServiceAccount":
+			bySAName[unst.GetName()] = ref
+		case secretKind, BundleSecretKind:
+			// NewStepResourceFromObject emits bundle Secrets with the synthetic BundleSecret kind.
+			byConfigRef[secretKind+"/"+unst.GetName()] = ref
+		case "ConfigMap":
+			byConfigRef[s.Kind+"/"+unst.GetName()] = ref
+		}
+	}
+
+	for i, s := range steps {
+		ref := stepRef(s, namespaces[i])
+		if ref == csvRef {
+			continue
+		}
+		g.addEdge(ref, csvRef)
+
+		unst := decoded[i]
+		if ns := namespaces[i]; ns != "" {
+			if nsRef, ok := byNamespace[ns]; ok {
+				g.addEdge(ref, nsRef)
+			}
+		}
+
+		if crdName, ok := crdNameByKind[s.Kind]; ok {
+			if crdRef, ok := byCRDName[crdName]; ok {
+				g.addEdge(ref, crdRef)
+			}
+		}
+
+		switch s.Kind {
+		case "RoleBinding", "ClusterRoleBinding":
+			for _, saName := range subjectServiceAccountNames(unst) {
+				if saRef, ok := bySAName[saName]; ok {
+					g.addEdge(ref, saRef)
+				}
+			}
+		case "Deployment":
+			for _, volumeRefName := range deploymentConfigRefs(unst) {
+				if cmRef, ok := byConfigRef["ConfigMap/"+volumeRefName]; ok {
+					g.addEdge(ref, cmRef)
+				}
+				if secretRef, ok := byConfigRef[secretKind+"/"+volumeRefName]; ok {
+					g.addEdge(ref, secretRef)
+				}
+			}
+		}
+	}
+
+	return g, nil
+}
+
+func (g *StepGraph) addEdge(from, to StepRef) {
+	for _, existing := range g.dependsOn[from] {
+		if existing == to {
+			return
+		}
+	}
+	g.dependsOn[from] = append(g.dependsOn[from], to)
+}
+
+// DependsOn returns the refs that ref directly depends on.
+func (g *StepGraph) DependsOn(ref StepRef) []StepRef {
+	return g.dependsOn[ref]
+}
+
+// TopoSort returns the steps given to BuildStepGraph, and their namespaces (index-aligned, as with
+// NewStepResourceFromBundle), ordered so that every step appears after everything it depends on, or a
+// *CycleError if the dependency graph isn't acyclic. Steps are visited depth-first in BuildStepGraph
+// order and each is emitted right after its dependencies, so a dependency of a later step can be
+// emitted ahead of an unrelated step that preceded it in BuildStepGraph order.
+func (g *StepGraph) TopoSort() ([]v1alpha1.StepResource, []string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[StepRef]int, len(g.order))
+	order := make([]v1alpha1.StepResource, 0, len(g.order))
+	namespaces := make([]string, 0, len(g.order))
+
+	var path []StepRef
+	var visit func(ref StepRef) error
+	visit = func(ref StepRef) error {
+		switch state[ref] {
+		case visited:
+			return nil
+		case visiting:
+			return &CycleError{Cycle: append(append([]StepRef{}, path...), ref)}
+		}
+		state[ref] = visiting
+		path = append(path, ref)
+		for _, dep := range g.dependsOn[ref] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[ref] = visited
+		order = append(order, g.steps[ref])
+		namespaces = append(namespaces, ref.Namespace)
+		return nil
+	}
+
+	for _, ref := range g.order {
+		if err := visit(ref); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return order, namespaces, nil
+}
+
+func decodeStepManifest(s v1alpha1.StepResource) (*unstructured.Unstructured, error) {
+	dec := yaml.NewYAMLOrJSONDecoder(strings.NewReader(s.Manifest), 10)
+	unst := &unstructured.Unstructured{}
+	if err := dec.Decode(unst); err != nil {
+		return nil, err
+	}
+	return unst, nil
+}
+
+// subjectServiceAccountNames returns the names of every ServiceAccount subject on a RoleBinding or
+// ClusterRoleBinding.
+func subjectServiceAccountNames(unst *unstructured.Unstructured) []string {
+	subjects, _, _ := unstructured.NestedSlice(unst.Object, "subjects")
+	var names []string
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if kind, _, _ := unstructured.NestedString(subject, "kind"); kind != "ServiceAccount" {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(subject, "name"); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// deploymentConfigRefs returns the names of every Secret/ConfigMap a Deployment's pod spec mounts as a
+// volume or references wholesale via envFrom.
+func deploymentConfigRefs(unst *unstructured.Unstructured) []string {
+	var refs []string
+
+	volumes, _, _ := unstructured.NestedSlice(unst.Object, "spec", "template", "spec", "volumes")
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(volume, "secret", "secretName"); name != "" {
+			refs = append(refs, name)
+		}
+		if name, _, _ := unstructured.NestedString(volume, "configMap", "name"); name != "" {
+			refs = append(refs, name)
+		}
+	}
+
+	containers, _, _ := unstructured.NestedSlice(unst.Object, "spec", "template", "spec", "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		envFrom, _, _ := unstructured.NestedSlice(container, "envFrom")
+		for _, e := range envFrom {
+			source, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, _, _ := unstructured.NestedString(source, "secretRef", "name"); name != "" {
+				refs = append(refs, name)
+			}
+			if name, _, _ := unstructured.NestedString(source, "configMapRef", "name"); name != "" {
+				refs = append(refs, name)
+			}
+		}
+	}
+
+	return refs
+}
@@ -0,0 +1,181 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stepResource(kind, name, manifest string) v1alpha1.StepResource {
+	return v1alpha1.StepResource{Kind: kind, Name: name, Manifest: manifest}
+}
+
+func TestSubjectServiceAccountNames(t *testing.T) {
+	unst, err := decodeStepManifest(stepResource("RoleBinding", "example", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: example
+subjects:
+- kind: ServiceAccount
+  name: sa-a
+- kind: User
+  name: not-a-service-account
+- kind: ServiceAccount
+  name: sa-b
+`))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"sa-a", "sa-b"}, subjectServiceAccountNames(unst))
+}
+
+func TestDeploymentConfigRefs(t *testing.T) {
+	unst, err := decodeStepManifest(stepResource("Deployment", "example", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+spec:
+  template:
+    spec:
+      volumes:
+      - name: v1
+        secret:
+          secretName: my-secret
+      - name: v2
+        configMap:
+          name: my-configmap
+      containers:
+      - name: c1
+        envFrom:
+        - secretRef:
+            name: env-secret
+        - configMapRef:
+            name: env-configmap
+`))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"my-secret", "my-configmap", "env-secret", "env-configmap"}, deploymentConfigRefs(unst))
+}
+
+func TestBuildStepGraphAndTopoSort(t *testing.T) {
+	csv := &v1alpha1.ClusterServiceVersion{}
+	csv.Name = "example-csv"
+	csv.Spec.CustomResourceDefinitions.Owned = []v1alpha1.CRDDescription{
+		{Name: "widgets.example.com", Version: "v1", Kind: "Widget"},
+	}
+	csvManifest := `
+apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: ` + csv.Name + `
+`
+
+	steps := []v1alpha1.StepResource{
+		stepResource(v1alpha1.ClusterServiceVersionKind, csv.Name, csvManifest),
+		stepResource("CustomResourceDefinition", "widgets.example.com", `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`),
+		stepResource("Widget", "my-widget", `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+  namespace: ns-a
+`),
+		stepResource("ServiceAccount", "my-sa", `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: my-sa
+  namespace: ns-a
+`),
+		stepResource("RoleBinding", "my-rb", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: my-rb
+  namespace: ns-a
+subjects:
+- kind: ServiceAccount
+  name: my-sa
+`),
+		stepResource(secretKind, "my-secret", `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: my-secret
+  namespace: ns-a
+`),
+		stepResource("Deployment", "my-deployment", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-deployment
+  namespace: ns-a
+spec:
+  template:
+    spec:
+      volumes:
+      - name: v1
+        secret:
+          secretName: my-secret
+`),
+	}
+	namespaces := []string{"", "", "ns-a", "ns-a", "ns-a", "ns-a", "ns-a"}
+
+	graph, err := BuildStepGraph(csv, steps, namespaces)
+	require.NoError(t, err)
+
+	ordered, orderedNamespaces, err := graph.TopoSort()
+	require.NoError(t, err)
+	require.Len(t, ordered, len(steps))
+	require.Len(t, orderedNamespaces, len(steps))
+
+	indexOf := func(kind, name string) int {
+		for i, s := range ordered {
+			if s.Kind == kind && s.Name == name {
+				return i
+			}
+		}
+		t.Fatalf("step %s/%s not found in ordered output", kind, name)
+		return -1
+	}
+
+	csvIdx := indexOf(v1alpha1.ClusterServiceVersionKind, csv.Name)
+	crdIdx := indexOf("CustomResourceDefinition", "widgets.example.com")
+	crIdx := indexOf("Widget", "my-widget")
+	saIdx := indexOf("ServiceAccount", "my-sa")
+	rbIdx := indexOf("RoleBinding", "my-rb")
+	secretIdx := indexOf(secretKind, "my-secret")
+	deployIdx := indexOf("Deployment", "my-deployment")
+
+	assert.Less(t, csvIdx, crdIdx)
+	assert.Less(t, crdIdx, crIdx)
+	assert.Less(t, saIdx, rbIdx)
+	assert.Less(t, secretIdx, deployIdx)
+}
+
+func TestTopoSortDetectsCycle(t *testing.T) {
+	g := &StepGraph{
+		steps: map[StepRef]v1alpha1.StepResource{
+			{Kind: "A", Name: "a"}: stepResource("A", "a", ""),
+			{Kind: "B", Name: "b"}: stepResource("B", "b", ""),
+		},
+		dependsOn: map[StepRef][]StepRef{
+			{Kind: "A", Name: "a"}: {{Kind: "B", Name: "b"}},
+			{Kind: "B", Name: "b"}: {{Kind: "A", Name: "a"}},
+		},
+		order: []StepRef{{Kind: "A", Name: "a"}, {Kind: "B", Name: "b"}},
+	}
+
+	_, _, err := g.TopoSort()
+	require.Error(t, err)
+	var cycleErr *CycleError
+	require.ErrorAs(t, err, &cycleErr)
+}
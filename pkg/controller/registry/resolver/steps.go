@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/yaml"
@@ -42,14 +43,26 @@ func init() {
 
 // NewStepResourceForObject returns a new StepResource for the provided object
 func NewStepResourceFromObject(obj runtime.Object, catalogSourceName, catalogSourceNamespace string) (v1alpha1.StepResource, error) {
+	return NewStepResourceFromObjectWithTransformers(obj, catalogSourceName, catalogSourceNamespace, TransformContext{}, nil)
+}
+
+// NewStepResourceFromObjectWithTransformers behaves like NewStepResourceFromObject, but first runs obj
+// through the given ordered chain of StepTransformers (e.g. to scope cluster-wide RBAC down to a target
+// namespace, or to inject standard labels) before it's serialized into a StepResource.
+func NewStepResourceFromObjectWithTransformers(obj runtime.Object, catalogSourceName, catalogSourceNamespace string, ctx TransformContext, transformers []StepTransformer) (v1alpha1.StepResource, error) {
 	var resource v1alpha1.StepResource
 
+	obj, err := applyStepTransformers(obj, ctx, transformers)
+	if err != nil {
+		return resource, err
+	}
+
 	// set up object serializer
 	serializer := k8sjson.NewSerializer(k8sjson.DefaultMetaFactory, scheme, scheme, false)
 
 	// create an object manifest
 	var manifest bytes.Buffer
-	err := serializer.Encode(obj, &manifest)
+	err = serializer.Encode(obj, &manifest)
 	if err != nil {
 		return resource, err
 	}
@@ -118,6 +131,13 @@ func V1alpha1CSVFromBundle(bundle *api.Bundle) (*v1alpha1.ClusterServiceVersion,
 // NewStepResourceFromBundle returns StepResources and related Namespaces indexed in the same order.
 // StepResources don't contain the resource namespace, which is required to uniquely identify a resource.
 func NewStepResourceFromBundle(bundle *api.Bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace string) ([]v1alpha1.StepResource, []string, error) {
+	return NewStepResourceFromBundleWithTransformers(bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace, TransformContext{}, nil)
+}
+
+// NewStepResourceFromBundleWithTransformers behaves like NewStepResourceFromBundle, but runs every
+// generated step's object through the given ordered chain of StepTransformers (e.g.
+// NamespaceScopingTransformer, LabelInjectionTransformer) before it's serialized into a StepResource.
+func NewStepResourceFromBundleWithTransformers(bundle *api.Bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace string, ctx TransformContext, transformers []StepTransformer) ([]v1alpha1.StepResource, []string, error) {
 	csv, err := V1alpha1CSVFromBundle(bundle)
 	if err != nil {
 		return nil, nil, err
@@ -137,7 +157,7 @@ func NewStepResourceFromBundle(bundle *api.Bundle, namespace, replaces, catalogS
 	annos[projection.PropertiesAnnotationKey] = anno
 	csv.SetAnnotations(annos)
 
-	csvStep, err := NewStepResourceFromObject(csv, catalogSourceName, catalogSourceNamespace)
+	csvStep, err := NewStepResourceFromObjectWithTransformers(csv, catalogSourceName, catalogSourceNamespace, ctx, transformers)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -157,14 +177,14 @@ func NewStepResourceFromBundle(bundle *api.Bundle, namespace, replaces, catalogS
 			continue
 		}
 
-		step, err := NewStepResourceFromObject(unst, catalogSourceName, catalogSourceNamespace)
+		step, err := NewStepResourceFromObjectWithTransformers(unst, catalogSourceName, catalogSourceNamespace, ctx, transformers)
 		if err != nil {
 			return nil, nil, err
 		}
 		steps = append(steps, step)
 	}
 
-	operatorServiceAccountSteps, err := NewServiceAccountStepResources(csv, catalogSourceName, catalogSourceNamespace)
+	operatorServiceAccountSteps, err := NewServiceAccountStepResourcesWithTransformers(csv, catalogSourceName, catalogSourceNamespace, ctx, transformers)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -179,10 +199,85 @@ func NewStepResourceFromBundle(bundle *api.Bundle, namespace, replaces, catalogS
 	return steps, namespaces, nil
 }
 
-func NewStepsFromBundle(bundle *api.Bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace string) ([]*v1alpha1.Step, error) {
-	bundleSteps, _, err := NewStepResourceFromBundle(bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace)
+// StepWarning is a human-readable warning attached to a generated step, e.g. because it targets a
+// deprecated or soon-to-be-removed API. It is returned alongside steps rather than embedded in
+// v1alpha1.Step; NewStepResourceFromBundle does not produce warnings and returns only steps and
+// namespaces.
+type StepWarning struct {
+	// Step identifies the resource the warning applies to. Deprecation warnings populate it with the
+	// full generated StepResource; verification warnings populate only its Group/Version/Kind/Name,
+	// since they can fire against a raw bundle manifest before a StepResource is otherwise generated for
+	// it (only Kind/Name for a declared digest whose manifest is absent from the bundle). It is the zero
+	// value for warnings not about any one manifest (e.g. a missing signature).
+	Step    v1alpha1.StepResource
+	Message string
+}
+
+// NewStepResourceFromBundleWithOptions behaves like NewStepResourceFromBundle but additionally applies
+// the cross-cutting checks and rewrites configured via opts: deprecated/removed GVK gating
+// (WithDeprecationChecker), target-cluster version constraints (WithClusterVersionConstraints), content
+// verification (WithVerificationPolicy), and step object rewriting (WithStepTransformers).
+func NewStepResourceFromBundleWithOptions(bundle *api.Bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace string, opts ...StepOption) ([]v1alpha1.StepResource, []string, []StepWarning, error) {
+	cfg := &StepGenerationOptions{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.clusterVersions != nil {
+		if err := checkVersionConstraints(bundle, cfg.clusterVersions); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var warnings []StepWarning
+	if cfg.verificationPolicy != "" {
+		verificationWarnings, err := verifyBundleContent(bundle, cfg.signatureVerifier, cfg.verificationPolicy)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		warnings = append(warnings, verificationWarnings...)
+	}
+
+	steps, namespaces, err := NewStepResourceFromBundleWithTransformers(bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace, cfg.transformContext, cfg.transformers)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+
+	if cfg.deprecationChecker == nil {
+		return steps, namespaces, warnings, nil
+	}
+	csv, err := V1alpha1CSVFromBundle(bundle)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	exemptGVKs := bundleOwnedGVKs(csv)
+	for _, step := range steps {
+		gvk := schema.GroupVersionKind{Group: step.Group, Version: step.Version, Kind: step.Kind}
+		if exemptGVKs[gvk] {
+			// The bundle's own custom resources aren't served by the cluster until its own CRD step
+			// has been applied and propagated; that's not the same thing as a removed API.
+			continue
+		}
+		deprecated, removed, message := cfg.deprecationChecker.CheckDeprecated(gvk)
+		if !deprecated {
+			continue
+		}
+		if removed && cfg.deprecationPolicy == DeprecationPolicyFail {
+			return nil, nil, nil, &DeprecatedAPIError{GVK: gvk, Message: message}
+		}
+		warnings = append(warnings, StepWarning{Step: step, Message: message})
+	}
+
+	return steps, namespaces, warnings, nil
+}
+
+// NewStepsFromBundle returns the (unqualified, always-required) steps to be populated into the
+// InstallPlan for a bundle. opts additionally enables the cross-cutting checks and rewrites documented
+// on NewStepResourceFromBundleWithOptions.
+func NewStepsFromBundle(bundle *api.Bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace string, opts ...StepOption) ([]*v1alpha1.Step, []StepWarning, error) {
+	bundleSteps, _, warnings, err := NewStepResourceFromBundleWithOptions(bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace, opts...)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var steps []*v1alpha1.Step
@@ -194,24 +289,43 @@ func NewStepsFromBundle(bundle *api.Bundle, namespace, replaces, catalogSourceNa
 		})
 	}
 
-	return steps, nil
+	return steps, warnings, nil
 }
 
-// NewQualifiedStepsFromBundle returns the steps to be populated into the InstallPlan for a bundle
-// Qualified means that steps may have been flaged as optional.
+// NewQualifiedStepsFromBundle returns the steps to be populated into the InstallPlan for a bundle.
+// Qualified means that steps may have been flagged as optional. Steps are ordered per the bundle's
+// dependency graph (see BuildStepGraph): the CSV first, then everything else in an order that respects
+// CRD-before-CR, ServiceAccount-before-(Cluster)RoleBinding, Namespace-before-namespaced-object and
+// Deployment-after-its-mounted-Secret/ConfigMap edges. opts additionally enables the cross-cutting
+// checks and rewrites documented on NewStepResourceFromBundleWithOptions.
 func NewQualifiedStepsFromBundle(bundle *api.Bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace string,
-	logger *logrus.Logger) ([]*v1alpha1.Step, error) {
-	bundleSteps, namespaces, err := NewStepResourceFromBundle(bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace)
+	logger *logrus.Logger, opts ...StepOption) ([]*v1alpha1.Step, []StepWarning, error) {
+	bundleSteps, namespaces, warnings, err := NewStepResourceFromBundleWithOptions(bundle, namespace, replaces, catalogSourceName, catalogSourceNamespace, opts...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	csv, err := V1alpha1CSVFromBundle(bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	graph, err := BuildStepGraph(csv, bundleSteps, namespaces)
+	if err != nil {
+		return nil, nil, err
+	}
+	orderedSteps, orderedNamespaces, err := graph.TopoSort()
+	if err != nil {
+		return nil, nil, err
 	}
+
 	var steps []*v1alpha1.Step
 	isOptFunc := isOptional(bundle.Properties, logger)
-	for i, s := range bundleSteps {
+	for i, s := range orderedSteps {
 		// Optional manifests are identified by:  group, kind, namespace (optional), name
-		// bundleSteps and namespaces share the same index (weak)
+		// orderedSteps and orderedNamespaces share the same index (weak)
 		var key manifestKey
-		if namespaces[i] == "" {
+		if orderedNamespaces[i] == "" {
 			key = manifestKey{
 				Group: s.Group,
 				Kind:  s.Kind,
@@ -221,36 +335,34 @@ func NewQualifiedStepsFromBundle(bundle *api.Bundle, namespace, replaces, catalo
 			key = manifestKey{
 				Group:     s.Group,
 				Kind:      s.Kind,
-				Namespace: namespaces[i],
+				Namespace: orderedNamespaces[i],
 				Name:      s.Name,
 			}
 		}
 		optional := isOptFunc(key)
 		logger.Debugf("key %s is optional: %t", key, optional)
 
-		// CSV should be positioned first
-		if s.Kind == v1alpha1.ClusterServiceVersionKind {
-			steps = append([]*v1alpha1.Step{{
-				Resolving: bundle.CsvName,
-				Resource:  s,
-				Optional:  optional,
-				Status:    v1alpha1.StepStatusUnknown,
-			}}, steps...)
-		} else {
-			steps = append(steps, &v1alpha1.Step{
-				Resolving: bundle.CsvName,
-				Resource:  s,
-				Optional:  optional,
-				Status:    v1alpha1.StepStatusUnknown,
-			})
-		}
+		steps = append(steps, &v1alpha1.Step{
+			Resolving: bundle.CsvName,
+			Resource:  s,
+			Optional:  optional,
+			Status:    v1alpha1.StepStatusUnknown,
+		})
 	}
 
-	return steps, nil
+	return steps, warnings, nil
 }
 
 // NewServiceAccountStepResources returns a list of step resources required to satisfy the RBAC requirements of the given CSV's InstallStrategy
 func NewServiceAccountStepResources(csv *v1alpha1.ClusterServiceVersion, catalogSourceName, catalogSourceNamespace string) ([]v1alpha1.StepResource, error) {
+	return NewServiceAccountStepResourcesWithTransformers(csv, catalogSourceName, catalogSourceNamespace, TransformContext{}, nil)
+}
+
+// NewServiceAccountStepResourcesWithTransformers behaves like NewServiceAccountStepResources, but runs
+// each generated RBAC object through the given ordered chain of StepTransformers (e.g.
+// NamespaceScopingTransformer to convert ClusterRole/ClusterRoleBinding objects into namespace-scoped
+// Role/RoleBindings) before it's serialized into a StepResource.
+func NewServiceAccountStepResourcesWithTransformers(csv *v1alpha1.ClusterServiceVersion, catalogSourceName, catalogSourceNamespace string, ctx TransformContext, transformers []StepTransformer) ([]v1alpha1.StepResource, error) {
 	var rbacSteps []v1alpha1.StepResource
 
 	operatorPermissions, err := RBACForClusterServiceVersion(csv)
@@ -260,35 +372,35 @@ func NewServiceAccountStepResources(csv *v1alpha1.ClusterServiceVersion, catalog
 
 	for _, perms := range operatorPermissions {
 		if perms.ServiceAccount.Name != "default" {
-			step, err := NewStepResourceFromObject(perms.ServiceAccount, catalogSourceName, catalogSourceNamespace)
+			step, err := NewStepResourceFromObjectWithTransformers(perms.ServiceAccount, catalogSourceName, catalogSourceNamespace, ctx, transformers)
 			if err != nil {
 				return nil, err
 			}
 			rbacSteps = append(rbacSteps, step)
 		}
 		for _, role := range perms.Roles {
-			step, err := NewStepResourceFromObject(role, catalogSourceName, catalogSourceNamespace)
+			step, err := NewStepResourceFromObjectWithTransformers(role, catalogSourceName, catalogSourceNamespace, ctx, transformers)
 			if err != nil {
 				return nil, err
 			}
 			rbacSteps = append(rbacSteps, step)
 		}
 		for _, roleBinding := range perms.RoleBindings {
-			step, err := NewStepResourceFromObject(roleBinding, catalogSourceName, catalogSourceNamespace)
+			step, err := NewStepResourceFromObjectWithTransformers(roleBinding, catalogSourceName, catalogSourceNamespace, ctx, transformers)
 			if err != nil {
 				return nil, err
 			}
 			rbacSteps = append(rbacSteps, step)
 		}
 		for _, clusterRole := range perms.ClusterRoles {
-			step, err := NewStepResourceFromObject(clusterRole, catalogSourceName, catalogSourceNamespace)
+			step, err := NewStepResourceFromObjectWithTransformers(clusterRole, catalogSourceName, catalogSourceNamespace, ctx, transformers)
 			if err != nil {
 				return nil, err
 			}
 			rbacSteps = append(rbacSteps, step)
 		}
 		for _, clusterRoleBinding := range perms.ClusterRoleBindings {
-			step, err := NewStepResourceFromObject(clusterRoleBinding, catalogSourceName, catalogSourceNamespace)
+			step, err := NewStepResourceFromObjectWithTransformers(clusterRoleBinding, catalogSourceName, catalogSourceNamespace, ctx, transformers)
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,151 @@
+package resolver
+
+import (
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	clusterRoleGroupKind        = schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}
+	clusterRoleBindingGroupKind = schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}
+)
+
+// TransformContext carries the information a StepTransformer needs to rewrite a step's object, beyond
+// the object itself: what namespace(s) the operator is being installed into and what catalog produced
+// the step.
+type TransformContext struct {
+	// TargetNamespaces is the set of namespaces the operator is watching/operating against, as derived
+	// from the Subscription/OperatorGroup install mode (e.g. the single namespace for
+	// InstallModeTypeSingleNamespace).
+	TargetNamespaces []string
+
+	CatalogSourceName      string
+	CatalogSourceNamespace string
+}
+
+// StepTransformer rewrites a step's object before it's serialized into a StepResource. Transformers are
+// applied in order; a transformer that doesn't apply to obj should return it unchanged.
+type StepTransformer func(obj runtime.Object, ctx TransformContext) (runtime.Object, error)
+
+// applyStepTransformers runs obj through the given chain of transformers in order, threading the result
+// of each into the next.
+func applyStepTransformers(obj runtime.Object, ctx TransformContext, transformers []StepTransformer) (runtime.Object, error) {
+	for _, transform := range transformers {
+		var err error
+		obj, err = transform(obj, ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// NamespaceScopingTransformer converts a ClusterRole/ClusterRoleBinding step into the equivalent
+// Role/RoleBinding scoped to the operator's single target namespace, mirroring the implicit RBAC model
+// OperatorGroup applies for InstallModeTypeSingleNamespace/InstallModeTypeOwnNamespace operators.
+// Objects of any other kind, and ClusterRole(Binding)s when the operator doesn't have exactly one
+// target namespace (e.g. AllNamespaces or MultiNamespace install modes), are returned unchanged.
+//
+// ClusterRole/ClusterRoleBinding is matched by GroupKind rather than Go type, because obj may be either
+// the typed rbacv1 objects NewServiceAccountStepResourcesWithTransformers synthesizes from the CSV's
+// install permissions, or an *unstructured.Unstructured decoded straight off a raw bundle manifest (see
+// NewStepResourceFromBundleWithTransformers) - matching on concrete type alone would silently leave
+// bundle-shipped ClusterRole(Binding)s cluster-scoped.
+func NamespaceScopingTransformer(obj runtime.Object, ctx TransformContext) (runtime.Object, error) {
+	if len(ctx.TargetNamespaces) != 1 {
+		// The single-object StepTransformer signature can't fan one ClusterRole out into N Roles, so
+		// scoping only applies when there's exactly one target namespace to scope to.
+		return obj, nil
+	}
+	targetNamespace := ctx.TargetNamespaces[0]
+
+	switch t := obj.(type) {
+	case *rbacv1.ClusterRole:
+		return scopeClusterRole(t, targetNamespace), nil
+	case *rbacv1.ClusterRoleBinding:
+		return scopeClusterRoleBinding(t, targetNamespace), nil
+	case *unstructured.Unstructured:
+		switch t.GroupVersionKind().GroupKind() {
+		case clusterRoleGroupKind:
+			cr := &rbacv1.ClusterRole{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(t.Object, cr); err != nil {
+				return nil, fmt.Errorf("failed to convert %s/%s to ClusterRole: %w", t.GetNamespace(), t.GetName(), err)
+			}
+			return toUnstructured(scopeClusterRole(cr, targetNamespace))
+		case clusterRoleBindingGroupKind:
+			crb := &rbacv1.ClusterRoleBinding{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(t.Object, crb); err != nil {
+				return nil, fmt.Errorf("failed to convert %s/%s to ClusterRoleBinding: %w", t.GetNamespace(), t.GetName(), err)
+			}
+			return toUnstructured(scopeClusterRoleBinding(crb, targetNamespace))
+		default:
+			return obj, nil
+		}
+	default:
+		return obj, nil
+	}
+}
+
+func scopeClusterRole(cr *rbacv1.ClusterRole, namespace string) *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+		ObjectMeta: objectMetaInNamespace(cr.ObjectMeta, namespace),
+		Rules:      cr.Rules,
+	}
+}
+
+func scopeClusterRoleBinding(crb *rbacv1.ClusterRoleBinding, namespace string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+		ObjectMeta: objectMetaInNamespace(crb.ObjectMeta, namespace),
+		Subjects:   crb.Subjects,
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: crb.RoleRef.APIGroup,
+			Kind:     "Role",
+			Name:     crb.RoleRef.Name,
+		},
+	}
+}
+
+func objectMetaInNamespace(meta metav1.ObjectMeta, namespace string) metav1.ObjectMeta {
+	meta.Namespace = namespace
+	return meta
+}
+
+// toUnstructured converts a typed object (with its TypeMeta already populated) into an
+// *unstructured.Unstructured, so NamespaceScopingTransformer's unstructured-input path returns the same
+// kind of runtime.Object it was given.
+func toUnstructured(obj interface{}) (runtime.Object, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: data}, nil
+}
+
+// LabelInjectionTransformer returns a StepTransformer that sets the given labels on every object it
+// sees, without overwriting any label the object already carries.
+func LabelInjectionTransformer(labels map[string]string) StepTransformer {
+	return func(obj runtime.Object, _ TransformContext) (runtime.Object, error) {
+		metaObj, ok := obj.(metav1.Object)
+		if !ok {
+			return obj, nil
+		}
+		existing := metaObj.GetLabels()
+		if existing == nil {
+			existing = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			if _, ok := existing[k]; !ok {
+				existing[k] = v
+			}
+		}
+		metaObj.SetLabels(existing)
+		return obj, nil
+	}
+}
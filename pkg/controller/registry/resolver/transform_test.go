@@ -0,0 +1,145 @@
+package resolver
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceScopingTransformerNoOpWithoutSingleTargetNamespace(t *testing.T) {
+	cr := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster-role"}}
+
+	out, err := NamespaceScopingTransformer(cr, TransformContext{TargetNamespaces: []string{"ns-a", "ns-b"}})
+	require.NoError(t, err)
+	assert.Same(t, runtime.Object(cr), out)
+}
+
+func TestNamespaceScopingTransformerTypedClusterRole(t *testing.T) {
+	cr := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster-role"},
+		Rules:      []rbacv1.PolicyRule{{Verbs: []string{"get"}, Resources: []string{"pods"}}},
+	}
+
+	out, err := NamespaceScopingTransformer(cr, TransformContext{TargetNamespaces: []string{"ns-a"}})
+	require.NoError(t, err)
+
+	role, ok := out.(*rbacv1.Role)
+	require.True(t, ok, "expected *rbacv1.Role, got %T", out)
+	assert.Equal(t, "ns-a", role.Namespace)
+	assert.Equal(t, "my-cluster-role", role.Name)
+	assert.Equal(t, cr.Rules, role.Rules)
+}
+
+func TestNamespaceScopingTransformerTypedClusterRoleBinding(t *testing.T) {
+	crb := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster-role-binding"},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: "my-sa"}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "my-cluster-role"},
+	}
+
+	out, err := NamespaceScopingTransformer(crb, TransformContext{TargetNamespaces: []string{"ns-a"}})
+	require.NoError(t, err)
+
+	binding, ok := out.(*rbacv1.RoleBinding)
+	require.True(t, ok, "expected *rbacv1.RoleBinding, got %T", out)
+	assert.Equal(t, "ns-a", binding.Namespace)
+	assert.Equal(t, crb.Subjects, binding.Subjects)
+	assert.Equal(t, "Role", binding.RoleRef.Kind)
+	assert.Equal(t, "my-cluster-role", binding.RoleRef.Name)
+}
+
+func TestNamespaceScopingTransformerUnstructuredClusterRole(t *testing.T) {
+	unst := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata": map[string]interface{}{
+			"name": "bundle-cluster-role",
+		},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"verbs":     []interface{}{"get", "list"},
+				"resources": []interface{}{"pods"},
+			},
+		},
+	}}
+
+	out, err := NamespaceScopingTransformer(unst, TransformContext{TargetNamespaces: []string{"ns-a"}})
+	require.NoError(t, err)
+
+	result, ok := out.(*unstructured.Unstructured)
+	require.True(t, ok, "expected *unstructured.Unstructured, got %T", out)
+	assert.Equal(t, "Role", result.GetKind())
+	assert.Equal(t, "ns-a", result.GetNamespace())
+	assert.Equal(t, "bundle-cluster-role", result.GetName())
+
+	rules, found, err := unstructured.NestedSlice(result.Object, "rules")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Len(t, rules, 1)
+}
+
+func TestNamespaceScopingTransformerUnstructuredClusterRoleBinding(t *testing.T) {
+	unst := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRoleBinding",
+		"metadata": map[string]interface{}{
+			"name": "bundle-cluster-role-binding",
+		},
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "ServiceAccount", "name": "my-sa"},
+		},
+		"roleRef": map[string]interface{}{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     "ClusterRole",
+			"name":     "bundle-cluster-role",
+		},
+	}}
+
+	out, err := NamespaceScopingTransformer(unst, TransformContext{TargetNamespaces: []string{"ns-a"}})
+	require.NoError(t, err)
+
+	result, ok := out.(*unstructured.Unstructured)
+	require.True(t, ok, "expected *unstructured.Unstructured, got %T", out)
+	assert.Equal(t, "RoleBinding", result.GetKind())
+	assert.Equal(t, "ns-a", result.GetNamespace())
+
+	roleRefKind, found, err := unstructured.NestedString(result.Object, "roleRef", "kind")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "Role", roleRefKind)
+}
+
+func TestNamespaceScopingTransformerUnstructuredOtherKindUnchanged(t *testing.T) {
+	unst := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": "my-configmap",
+		},
+	}}
+
+	out, err := NamespaceScopingTransformer(unst, TransformContext{TargetNamespaces: []string{"ns-a"}})
+	require.NoError(t, err)
+	assert.Same(t, runtime.Object(unst), out)
+}
+
+func TestLabelInjectionTransformerDoesNotOverwriteExistingLabels(t *testing.T) {
+	cr := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{
+		Name:   "my-cluster-role",
+		Labels: map[string]string{"keep": "me"},
+	}}
+	transformer := LabelInjectionTransformer(map[string]string{"keep": "overwritten", "added": "yes"})
+
+	out, err := transformer(cr, TransformContext{})
+	require.NoError(t, err)
+
+	role := out.(*rbacv1.ClusterRole)
+	assert.Equal(t, "me", role.Labels["keep"])
+	assert.Equal(t, "yes", role.Labels["added"])
+}
@@ -0,0 +1,248 @@
+package resolver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+const (
+	// artifactDigestsProperty carries a canonical JSON list of artifactDigest describing the expected
+	// content hash of every manifest in the bundle.
+	artifactDigestsProperty = "olm.artifact.digests"
+	// artifactSignatureProperty carries a detached signature (PGP or cosign, depending on the
+	// SignatureVerifier used) over the artifactDigestsProperty value.
+	artifactSignatureProperty = "olm.artifact.signature"
+)
+
+// VerificationPolicy controls how a digest or signature mismatch is handled during step generation.
+type VerificationPolicy string
+
+const (
+	// VerificationPolicyOff skips content verification entirely.
+	VerificationPolicyOff VerificationPolicy = "off"
+	// VerificationPolicyWarn surfaces a StepWarning on mismatch but still emits the step.
+	VerificationPolicyWarn VerificationPolicy = "warn"
+	// VerificationPolicyEnforce fails resolution on mismatch.
+	VerificationPolicyEnforce VerificationPolicy = "enforce"
+)
+
+// artifactDigest is one entry of the olm.artifact.digests property.
+type artifactDigest struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	SHA256    string `json:"sha256"`
+}
+
+// SignatureVerifier verifies a detached signature over a canonicalized artifactDigestsProperty payload.
+// Implementations typically wrap a PGP keyring or a cosign public key/KMS reference.
+type SignatureVerifier interface {
+	Verify(payload []byte, signature string) error
+}
+
+// DigestMismatchError is returned when a bundle manifest's computed content hash doesn't match its
+// declared olm.artifact.digests entry.
+type DigestMismatchError struct {
+	Kind, Name, Namespace string
+	Want, Got             string
+}
+
+func (e *DigestMismatchError) Error() string {
+	id := e.Kind + "/" + e.Name
+	if e.Namespace != "" {
+		id = e.Namespace + "/" + id
+	}
+	return fmt.Sprintf("digest mismatch for %s: declared sha256:%s, computed sha256:%s", id, e.Want, e.Got)
+}
+
+// SignatureVerificationError is returned when a bundle's olm.artifact.signature property fails to
+// verify against its olm.artifact.digests payload.
+type SignatureVerificationError struct {
+	Err error
+}
+
+func (e *SignatureVerificationError) Error() string {
+	return fmt.Sprintf("signature verification failed: %v", e.Err)
+}
+
+func (e *SignatureVerificationError) Unwrap() error {
+	return e.Err
+}
+
+// UndeclaredManifestError is returned when a bundle manifest has no corresponding entry in
+// olm.artifact.digests. A signed digest list that doesn't cover every manifest can't rule out a
+// substituted or added object, so an undeclared manifest is treated the same as a mismatched one.
+type UndeclaredManifestError struct {
+	Kind, Name, Namespace string
+}
+
+func (e *UndeclaredManifestError) Error() string {
+	id := e.Kind + "/" + e.Name
+	if e.Namespace != "" {
+		id = e.Namespace + "/" + id
+	}
+	return fmt.Sprintf("manifest %s has no entry in %s", id, artifactDigestsProperty)
+}
+
+// MissingManifestError is returned when olm.artifact.digests declares a manifest that isn't actually
+// present in the bundle, which is as suspicious as an undeclared one: it means the signed digest list
+// and the bundle's content have diverged.
+type MissingManifestError struct {
+	Kind, Name, Namespace string
+}
+
+func (e *MissingManifestError) Error() string {
+	id := e.Kind + "/" + e.Name
+	if e.Namespace != "" {
+		id = e.Namespace + "/" + id
+	}
+	return fmt.Sprintf("%s declares %s, but the bundle does not contain it", artifactDigestsProperty, id)
+}
+
+// MissingSignatureError is returned when a SignatureVerifier is configured and the bundle declares
+// olm.artifact.digests but has no olm.artifact.signature property. olm.artifact.digests lives in the same untrusted bundle property list
+// as the manifests it attests, so a digest check with no verified signature behind it provides no
+// integrity guarantee at all.
+type MissingSignatureError struct{}
+
+func (e *MissingSignatureError) Error() string {
+	return fmt.Sprintf("a SignatureVerifier is configured but the bundle has no %s property", artifactSignatureProperty)
+}
+
+// verifyBundleContent checks every manifest in bundle.Object against the declared
+// olm.artifact.digests/olm.artifact.signature properties, per policy. A bundle with no
+// olm.artifact.digests property is always considered verified (there's nothing declared to check
+// against).
+func verifyBundleContent(bundle *api.Bundle, verifier SignatureVerifier, policy VerificationPolicy) ([]StepWarning, error) {
+	if policy == VerificationPolicyOff {
+		return nil, nil
+	}
+
+	raw, ok := getPropertyValue(bundle.Properties, artifactDigestsProperty)
+	if !ok {
+		return nil, nil
+	}
+
+	var digests []artifactDigest
+	if err := json.Unmarshal([]byte(raw), &digests); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", artifactDigestsProperty, err)
+	}
+
+	// fail resolves a verification error per policy, attributing it to resource (the zero value if the
+	// error isn't about any one manifest, e.g. a missing signature).
+	fail := func(resource v1alpha1.StepResource, err error) ([]StepWarning, error) {
+		if policy == VerificationPolicyEnforce {
+			return nil, err
+		}
+		return []StepWarning{{Step: resource, Message: err.Error()}}, nil
+	}
+
+	var warnings []StepWarning
+	if verifier != nil {
+		var sigErr error
+		sig, ok := getPropertyValue(bundle.Properties, artifactSignatureProperty)
+		if !ok {
+			// Without a verified signature, olm.artifact.digests is just more untrusted bundle content:
+			// a missing signature can't be treated as "nothing to check against" the way a missing
+			// digest list is.
+			sigErr = &MissingSignatureError{}
+		} else if err := verifier.Verify([]byte(raw), sig); err != nil {
+			sigErr = &SignatureVerificationError{Err: err}
+		}
+		if sigErr != nil {
+			sigWarnings, err := fail(v1alpha1.StepResource{}, sigErr)
+			if err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, sigWarnings...)
+		}
+	}
+
+	declared := make(map[string]artifactDigest, len(digests))
+	for _, d := range digests {
+		declared[digestKey(d.Kind, d.Namespace, d.Name)] = d
+	}
+	matched := make(map[string]bool, len(digests))
+
+	for _, object := range bundle.Object {
+		dec := yaml.NewYAMLOrJSONDecoder(strings.NewReader(object), 10)
+		unst := &unstructured.Unstructured{}
+		if err := dec.Decode(unst); err != nil {
+			return nil, err
+		}
+		resource := v1alpha1.StepResource{
+			Kind:    unst.GetKind(),
+			Name:    unst.GetName(),
+			Group:   unst.GroupVersionKind().Group,
+			Version: unst.GroupVersionKind().Version,
+		}
+
+		key := digestKey(unst.GetKind(), unst.GetNamespace(), unst.GetName())
+		d, ok := declared[key]
+		if !ok {
+			// A manifest not covered by any declared digest can't be ruled out as a substitution or an
+			// injected addition, so it's treated the same as a mismatch rather than silently skipped.
+			undeclaredWarnings, err := fail(resource, &UndeclaredManifestError{Kind: unst.GetKind(), Name: unst.GetName(), Namespace: unst.GetNamespace()})
+			if err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, undeclaredWarnings...)
+			continue
+		}
+		matched[key] = true
+
+		got, err := canonicalDigest(unst)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute digest for %s/%s: %w", unst.GetKind(), unst.GetName(), err)
+		}
+		if got != d.SHA256 {
+			mismatchWarnings, err := fail(resource, &DigestMismatchError{
+				Kind: unst.GetKind(), Name: unst.GetName(), Namespace: unst.GetNamespace(),
+				Want: d.SHA256, Got: got,
+			})
+			if err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, mismatchWarnings...)
+		}
+	}
+
+	for key, d := range declared {
+		if matched[key] {
+			continue
+		}
+		resource := v1alpha1.StepResource{Kind: d.Kind, Name: d.Name}
+		missingWarnings, err := fail(resource, &MissingManifestError{Kind: d.Kind, Name: d.Name, Namespace: d.Namespace})
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, missingWarnings...)
+	}
+
+	return warnings, nil
+}
+
+// canonicalDigest returns the hex-encoded SHA-256 of obj's canonical (sorted-key) JSON encoding.
+// encoding/json already sorts map keys when marshaling, so a plain Marshal of the unstructured content
+// is canonical.
+func canonicalDigest(obj *unstructured.Unstructured) (string, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func digestKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
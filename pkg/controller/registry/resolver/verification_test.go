@@ -0,0 +1,161 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+const testDeploymentManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-deployment
+`
+
+func bundleWithDigests(digestsJSON string, extraProperties ...*api.Property) *api.Bundle {
+	properties := append([]*api.Property{
+		{Type: artifactDigestsProperty, Value: digestsJSON},
+	}, extraProperties...)
+	return &api.Bundle{
+		Object:     []string{testDeploymentManifest},
+		Properties: properties,
+	}
+}
+
+// deploymentDigest is the canonicalDigest of testDeploymentManifest, computed once here so test cases
+// can assert against a known-good value without recomputing it inline.
+func deploymentDigest(t *testing.T) string {
+	t.Helper()
+	unst, err := decodeStepManifest(stepResource("Deployment", "my-deployment", testDeploymentManifest))
+	require.NoError(t, err)
+	digest, err := canonicalDigest(unst)
+	require.NoError(t, err)
+	return digest
+}
+
+func TestVerifyBundleContentNoDigestsPropertyIsVerified(t *testing.T) {
+	bundle := &api.Bundle{Object: []string{testDeploymentManifest}}
+	warnings, err := verifyBundleContent(bundle, nil, VerificationPolicyEnforce)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestVerifyBundleContentPolicyOffSkipsEverything(t *testing.T) {
+	bundle := bundleWithDigests(`[{"kind":"Deployment","name":"my-deployment","sha256":"deadbeef"}]`)
+	warnings, err := verifyBundleContent(bundle, nil, VerificationPolicyOff)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestVerifyBundleContentMatchingDigest(t *testing.T) {
+	digest := deploymentDigest(t)
+	bundle := bundleWithDigests(`[{"kind":"Deployment","name":"my-deployment","sha256":"` + digest + `"}]`)
+
+	warnings, err := verifyBundleContent(bundle, nil, VerificationPolicyEnforce)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestVerifyBundleContentDigestMismatch(t *testing.T) {
+	bundle := bundleWithDigests(`[{"kind":"Deployment","name":"my-deployment","sha256":"deadbeef"}]`)
+
+	_, err := verifyBundleContent(bundle, nil, VerificationPolicyEnforce)
+	require.Error(t, err)
+	var mismatchErr *DigestMismatchError
+	require.ErrorAs(t, err, &mismatchErr)
+
+	warnings, err := verifyBundleContent(bundle, nil, VerificationPolicyWarn)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "Deployment", warnings[0].Step.Kind)
+	assert.Equal(t, "my-deployment", warnings[0].Step.Name)
+}
+
+func TestVerifyBundleContentUndeclaredManifest(t *testing.T) {
+	bundle := bundleWithDigests(`[]`)
+
+	_, err := verifyBundleContent(bundle, nil, VerificationPolicyEnforce)
+	require.Error(t, err)
+	var undeclaredErr *UndeclaredManifestError
+	require.ErrorAs(t, err, &undeclaredErr)
+
+	warnings, err := verifyBundleContent(bundle, nil, VerificationPolicyWarn)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "Deployment", warnings[0].Step.Kind)
+}
+
+func TestVerifyBundleContentMissingManifest(t *testing.T) {
+	digest := deploymentDigest(t)
+	bundle := bundleWithDigests(`[{"kind":"Deployment","name":"my-deployment","sha256":"` + digest + `"},` +
+		`{"kind":"Service","name":"my-service","sha256":"irrelevant"}]`)
+
+	_, err := verifyBundleContent(bundle, nil, VerificationPolicyEnforce)
+	require.Error(t, err)
+	var missingErr *MissingManifestError
+	require.ErrorAs(t, err, &missingErr)
+
+	warnings, err := verifyBundleContent(bundle, nil, VerificationPolicyWarn)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "Service", warnings[0].Step.Kind)
+	assert.Equal(t, "my-service", warnings[0].Step.Name)
+}
+
+type fakeVerifier struct {
+	err error
+}
+
+func (f *fakeVerifier) Verify(payload []byte, signature string) error {
+	return f.err
+}
+
+func TestVerifyBundleContentMissingSignatureIsHardFailureUnderEnforce(t *testing.T) {
+	digest := deploymentDigest(t)
+	bundle := bundleWithDigests(`[{"kind":"Deployment","name":"my-deployment","sha256":"` + digest + `"}]`)
+
+	_, err := verifyBundleContent(bundle, &fakeVerifier{}, VerificationPolicyEnforce)
+	require.Error(t, err)
+	var missingSigErr *MissingSignatureError
+	require.ErrorAs(t, err, &missingSigErr)
+}
+
+func TestVerifyBundleContentMissingSignatureWarnsUnderWarnPolicy(t *testing.T) {
+	digest := deploymentDigest(t)
+	bundle := bundleWithDigests(`[{"kind":"Deployment","name":"my-deployment","sha256":"` + digest + `"}]`)
+
+	warnings, err := verifyBundleContent(bundle, &fakeVerifier{}, VerificationPolicyWarn)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].Message, "SignatureVerifier")
+}
+
+func TestVerifyBundleContentSignaturePresentButInvalid(t *testing.T) {
+	digest := deploymentDigest(t)
+	bundle := bundleWithDigests(
+		`[{"kind":"Deployment","name":"my-deployment","sha256":"`+digest+`"}]`,
+		&api.Property{Type: artifactSignatureProperty, Value: "bad-signature"},
+	)
+
+	_, err := verifyBundleContent(bundle, &fakeVerifier{err: errors.New("signature does not match")}, VerificationPolicyEnforce)
+	require.Error(t, err)
+	var sigErr *SignatureVerificationError
+	require.ErrorAs(t, err, &sigErr)
+}
+
+func TestVerifyBundleContentValidSignaturePasses(t *testing.T) {
+	digest := deploymentDigest(t)
+	bundle := bundleWithDigests(
+		`[{"kind":"Deployment","name":"my-deployment","sha256":"`+digest+`"}]`,
+		&api.Property{Type: artifactSignatureProperty, Value: "good-signature"},
+	)
+
+	warnings, err := verifyBundleContent(bundle, &fakeVerifier{}, VerificationPolicyEnforce)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
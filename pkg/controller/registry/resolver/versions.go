@@ -0,0 +1,235 @@
+package resolver
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+const (
+	// ocpVersionsProperty carries a bundle's declared OpenShift version range, e.g. "=v4.10",
+	// "v4.8-v4.12" or "v4.9,v4.11".
+	ocpVersionsProperty = "com.redhat.openshift.versions"
+	// kubeVersionsProperty is the vendor-neutral equivalent of ocpVersionsProperty, expressed directly
+	// in Kubernetes versions.
+	kubeVersionsProperty = "olm.kubeversions"
+)
+
+// ocpToKubeMinor maps an OpenShift minor version to the Kubernetes minor version it ships, used to
+// translate a com.redhat.openshift.versions range into a kubeVersion range for evaluation against the
+// target cluster.
+var ocpToKubeMinor = map[int]int{
+	8:  19,
+	9:  20,
+	10: 21,
+	11: 22,
+	12: 23,
+	13: 24,
+	14: 25,
+	15: 26,
+	16: 28,
+}
+
+// simpleVersion is a major.minor version, which is all the precision bundle version ranges and
+// ocpToKubeMinor are expressed in.
+type simpleVersion struct {
+	major, minor int
+}
+
+func (v simpleVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+func (v simpleVersion) less(o simpleVersion) bool {
+	if v.major != o.major {
+		return v.major < o.major
+	}
+	return v.minor < o.minor
+}
+
+func (v simpleVersion) greater(o simpleVersion) bool {
+	return o.less(v)
+}
+
+// unboundedVersion stands in for an open-ended end of a range, e.g. the upper bound of "v4.8-" (meaning
+// "v4.8 and later").
+var unboundedVersion = simpleVersion{major: math.MaxInt32, minor: math.MaxInt32}
+
+// VersionConstraintError is returned when a bundle's declared version range excludes the target
+// cluster's Kubernetes version.
+type VersionConstraintError struct {
+	Property string
+	Range    string
+	Cluster  simpleVersion
+}
+
+func (e *VersionConstraintError) Error() string {
+	return fmt.Sprintf("bundle requires %s %s, which excludes target cluster version %s", e.Property, e.Range, e.Cluster)
+}
+
+// versionRange is a parsed bundle version constraint: a set of closed [min, max] intervals, any one of
+// which may be satisfied.
+type versionRange struct {
+	raw       string
+	intervals [][2]simpleVersion
+}
+
+func (r versionRange) contains(v simpleVersion) bool {
+	for _, interval := range r.intervals {
+		if !v.less(interval[0]) && !v.greater(interval[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVersionRange parses the four forms supported by com.redhat.openshift.versions / olm.kubeversions:
+//
+//	"=v4.10"        exact version
+//	"v4.8-v4.12"    closed range, inclusive
+//	"v4.8-"         open-ended range: v4.8 and later
+//	"v4.9,v4.11"    comma-separated list of exact versions or ranges, any of which may match
+func parseVersionRange(expr string) (*versionRange, error) {
+	r := &versionRange{raw: expr}
+	for _, clause := range strings.Split(expr, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+		if exact, ok := strings.CutPrefix(clause, "="); ok {
+			v, err := parseSimpleVersion(exact)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exact version %q in %q: %w", exact, expr, err)
+			}
+			r.intervals = append(r.intervals, [2]simpleVersion{v, v})
+			continue
+		}
+		if lo, hi, ok := strings.Cut(clause, "-"); ok {
+			loV, err := parseSimpleVersion(lo)
+			if err != nil {
+				return nil, fmt.Errorf("invalid lower bound %q in %q: %w", lo, expr, err)
+			}
+			hiV := unboundedVersion
+			if hi != "" {
+				hiV, err = parseSimpleVersion(hi)
+				if err != nil {
+					return nil, fmt.Errorf("invalid upper bound %q in %q: %w", hi, expr, err)
+				}
+			}
+			r.intervals = append(r.intervals, [2]simpleVersion{loV, hiV})
+			continue
+		}
+		v, err := parseSimpleVersion(clause)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q in %q: %w", clause, expr, err)
+		}
+		r.intervals = append(r.intervals, [2]simpleVersion{v, v})
+	}
+	if len(r.intervals) == 0 {
+		return nil, fmt.Errorf("empty version range %q", expr)
+	}
+	return r, nil
+}
+
+func parseSimpleVersion(s string) (simpleVersion, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(s, "v"))
+	major, minor, ok := strings.Cut(s, ".")
+	if !ok {
+		return simpleVersion{}, fmt.Errorf("expected major.minor, got %q", s)
+	}
+	majorN, err := strconv.Atoi(major)
+	if err != nil {
+		return simpleVersion{}, fmt.Errorf("invalid major version %q: %w", major, err)
+	}
+	minorN, err := strconv.Atoi(minor)
+	if err != nil {
+		return simpleVersion{}, fmt.Errorf("invalid minor version %q: %w", minor, err)
+	}
+	return simpleVersion{major: majorN, minor: minorN}, nil
+}
+
+// ocpRangeToKubeRange translates an OpenShift version range into the equivalent Kubernetes version
+// range via ocpToKubeMinor. ocpToKubeMinor only covers OpenShift 4.x; an interval with a bound outside
+// OCP major version 4, or whose minor has no known mapping, is dropped whole from the translated range.
+// An error is returned only when no interval of the range can be translated.
+func ocpRangeToKubeRange(ocp *versionRange) (*versionRange, error) {
+	kube := &versionRange{raw: ocp.raw}
+	for _, interval := range ocp.intervals {
+		lo, loOK := kubeMinorForOCP(interval[0])
+		if !loOK {
+			continue
+		}
+		hiV := unboundedVersion
+		if interval[1] != unboundedVersion {
+			hi, hiOK := kubeMinorForOCP(interval[1])
+			if !hiOK {
+				continue
+			}
+			hiV = simpleVersion{major: 1, minor: hi}
+		}
+		kube.intervals = append(kube.intervals, [2]simpleVersion{{major: 1, minor: lo}, hiV})
+	}
+	if len(kube.intervals) == 0 {
+		return nil, fmt.Errorf("no known Kubernetes mapping for OpenShift version range %q", ocp.raw)
+	}
+	return kube, nil
+}
+
+// kubeMinorForOCP looks up the Kubernetes minor version an OpenShift 4.x minor version ships, via
+// ocpToKubeMinor. ocpToKubeMinor is keyed on minor number alone, so any major other than 4 (a future
+// OpenShift major line whose minor numbers may overlap today's 4.x table) must be rejected explicitly
+// rather than silently matched against the wrong major's mapping.
+func kubeMinorForOCP(v simpleVersion) (int, bool) {
+	if v.major != 4 {
+		return 0, false
+	}
+	minor, ok := ocpToKubeMinor[v.minor]
+	return minor, ok
+}
+
+// ClusterVersionGetter resolves the Kubernetes version of the target cluster. It is typically backed by
+// a configv1.ClusterVersion lookup (translated via ocpToKubeMinor) or, failing that, discovery's
+// ServerVersion.
+type ClusterVersionGetter interface {
+	KubeVersion() (major, minor int, err error)
+}
+
+// checkVersionConstraints enforces a bundle's declared com.redhat.openshift.versions / olm.kubeversions
+// constraint against the target cluster's Kubernetes version, returning a VersionConstraintError if the
+// declared range excludes it. A bundle with no such property is always considered compatible.
+func checkVersionConstraints(bundle *api.Bundle, clusterVersions ClusterVersionGetter) error {
+	major, minor, err := clusterVersions.KubeVersion()
+	if err != nil {
+		return fmt.Errorf("failed to resolve target cluster Kubernetes version: %w", err)
+	}
+	cluster := simpleVersion{major: major, minor: minor}
+
+	if raw, ok := getPropertyValue(bundle.Properties, kubeVersionsProperty); ok {
+		r, err := parseVersionRange(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", kubeVersionsProperty, err)
+		}
+		if !r.contains(cluster) {
+			return &VersionConstraintError{Property: kubeVersionsProperty, Range: raw, Cluster: cluster}
+		}
+	}
+
+	if raw, ok := getPropertyValue(bundle.Properties, ocpVersionsProperty); ok {
+		ocpRange, err := parseVersionRange(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", ocpVersionsProperty, err)
+		}
+		kubeRange, err := ocpRangeToKubeRange(ocpRange)
+		if err != nil {
+			return fmt.Errorf("failed to translate %s to a Kubernetes version range: %w", ocpVersionsProperty, err)
+		}
+		if !kubeRange.contains(cluster) {
+			return &VersionConstraintError{Property: ocpVersionsProperty, Range: raw, Cluster: cluster}
+		}
+	}
+
+	return nil
+}
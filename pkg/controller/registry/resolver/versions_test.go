@@ -0,0 +1,129 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		expr        string
+		contains    []simpleVersion
+		excludes    []simpleVersion
+		expectError bool
+	}{
+		{
+			name:     "exact version",
+			expr:     "=v4.10",
+			contains: []simpleVersion{{4, 10}},
+			excludes: []simpleVersion{{4, 9}, {4, 11}},
+		},
+		{
+			name:     "closed range",
+			expr:     "v4.8-v4.12",
+			contains: []simpleVersion{{4, 8}, {4, 10}, {4, 12}},
+			excludes: []simpleVersion{{4, 7}, {4, 13}},
+		},
+		{
+			name:     "open-ended range",
+			expr:     "v4.8-",
+			contains: []simpleVersion{{4, 8}, {4, 20}, {5, 0}},
+			excludes: []simpleVersion{{4, 7}},
+		},
+		{
+			name:     "comma-separated exact versions",
+			expr:     "v4.9,v4.11",
+			contains: []simpleVersion{{4, 9}, {4, 11}},
+			excludes: []simpleVersion{{4, 10}, {4, 12}},
+		},
+		{
+			name:     "comma-separated ranges",
+			expr:     "v4.8-v4.9,v4.11-v4.12",
+			contains: []simpleVersion{{4, 8}, {4, 9}, {4, 11}, {4, 12}},
+			excludes: []simpleVersion{{4, 10}, {4, 13}},
+		},
+		{
+			name:        "empty expression",
+			expr:        "",
+			expectError: true,
+		},
+		{
+			name:        "malformed version",
+			expr:        "v4",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseVersionRange(tt.expr)
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, v := range tt.contains {
+				assert.Truef(t, r.contains(v), "expected range %q to contain %s", tt.expr, v)
+			}
+			for _, v := range tt.excludes {
+				assert.Falsef(t, r.contains(v), "expected range %q to exclude %s", tt.expr, v)
+			}
+		})
+	}
+}
+
+func TestOcpRangeToKubeRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		ocpExpr     string
+		contains    []simpleVersion
+		excludes    []simpleVersion
+		expectError bool
+	}{
+		{
+			name:     "closed range",
+			ocpExpr:  "v4.9-v4.11",
+			contains: []simpleVersion{{1, 20}, {1, 21}, {1, 22}},
+			excludes: []simpleVersion{{1, 19}, {1, 25}},
+		},
+		{
+			name:     "open-ended range",
+			ocpExpr:  "v4.14-",
+			contains: []simpleVersion{{1, 25}, {1, 30}},
+			excludes: []simpleVersion{{1, 24}},
+		},
+		{
+			name:        "no known mapping",
+			ocpExpr:     "=v4.999",
+			expectError: true,
+		},
+		{
+			name:        "rejects a non-4.x major even if the minor number overlaps the table",
+			ocpExpr:     "=v5.9",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ocp, err := parseVersionRange(tt.ocpExpr)
+			require.NoError(t, err)
+
+			kube, err := ocpRangeToKubeRange(ocp)
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, v := range tt.contains {
+				assert.Truef(t, kube.contains(v), "expected translated range for %q to contain %s", tt.ocpExpr, v)
+			}
+			for _, v := range tt.excludes {
+				assert.Falsef(t, kube.contains(v), "expected translated range for %q to exclude %s", tt.ocpExpr, v)
+			}
+		})
+	}
+}